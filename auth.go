@@ -0,0 +1,157 @@
+package main
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/viper"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/clientcredentials"
+)
+
+// awxAuthConfig configures how fetchAWXJobData authenticates against AWX,
+// covering HTTP Basic, a personal access token, and OAuth2 client
+// credentials, plus the TLS settings used regardless of auth type.
+type awxAuthConfig struct {
+	authType string // "basic", "bearer", or "oauth2"
+
+	username string
+	password string
+
+	token string
+
+	oauth2ClientID     string
+	oauth2ClientSecret string
+	oauth2TokenURL     string
+	oauth2Scopes       []string
+
+	insecureSkipVerify bool
+	caFile             string
+	certFile           string
+	keyFile            string
+}
+
+// loadAWXAuthConfig reads the awx.auth.* and awx.tls.* settings, resolving
+// any credential value that points at an environment variable ("env:NAME")
+// or a file ("file:/path") instead of embedding the secret directly in
+// YAML.
+func loadAWXAuthConfig() awxAuthConfig {
+	return awxAuthConfig{
+		authType: viper.GetString("awx.auth.type"),
+
+		username: resolveCredential(viper.GetString("awx.auth.username")),
+		password: resolveCredential(viper.GetString("awx.auth.password")),
+
+		token: resolveCredential(viper.GetString("awx.auth.token")),
+
+		oauth2ClientID:     resolveCredential(viper.GetString("awx.auth.oauth2.client_id")),
+		oauth2ClientSecret: resolveCredential(viper.GetString("awx.auth.oauth2.client_secret")),
+		oauth2TokenURL:     viper.GetString("awx.auth.oauth2.token_url"),
+		oauth2Scopes:       viper.GetStringSlice("awx.auth.oauth2.scopes"),
+
+		insecureSkipVerify: viper.GetBool("awx.tls.insecure_skip_verify"),
+		caFile:             viper.GetString("awx.tls.ca_file"),
+		certFile:           viper.GetString("awx.tls.cert_file"),
+		keyFile:            viper.GetString("awx.tls.key_file"),
+	}
+}
+
+// resolveCredential lets a config value point at an environment variable
+// ("env:NAME") or a file ("file:/path/to/secret") instead of requiring the
+// secret to be embedded directly in the YAML config.
+func resolveCredential(value string) string {
+	switch {
+	case strings.HasPrefix(value, "env:"):
+		return os.Getenv(strings.TrimPrefix(value, "env:"))
+	case strings.HasPrefix(value, "file:"):
+		data, err := os.ReadFile(strings.TrimPrefix(value, "file:"))
+		if err != nil {
+			log.Printf("Error reading credential file %q: %v", value, err)
+			return ""
+		}
+		return strings.TrimSpace(string(data))
+	default:
+		return value
+	}
+}
+
+// newAWXHTTPClient builds the http.Client used to talk to AWX. It wires up
+// TLS (custom CA bundle, mTLS client certs, InsecureSkipVerify) and, for
+// auth.type "oauth2", wraps the transport so the access token is fetched
+// and transparently refreshed via the client-credentials grant.
+func newAWXHTTPClient(auth awxAuthConfig, timeout time.Duration) (*http.Client, error) {
+	tlsConfig, err := buildTLSConfig(auth)
+	if err != nil {
+		return nil, err
+	}
+
+	transport := &http.Transport{TLSClientConfig: tlsConfig}
+
+	if auth.authType == "oauth2" {
+		oauthCfg := clientcredentials.Config{
+			ClientID:     auth.oauth2ClientID,
+			ClientSecret: auth.oauth2ClientSecret,
+			TokenURL:     auth.oauth2TokenURL,
+			Scopes:       auth.oauth2Scopes,
+		}
+		ctx := context.WithValue(context.Background(), oauth2.HTTPClient, &http.Client{
+			Transport: transport,
+			Timeout:   timeout,
+		})
+		client := oauthCfg.Client(ctx)
+		client.Timeout = timeout
+		return client, nil
+	}
+
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+// buildTLSConfig assembles the tls.Config shared by every auth type: an
+// optional custom CA bundle, optional mTLS client certificate, and the
+// InsecureSkipVerify escape hatch for self-signed AWX deployments.
+func buildTLSConfig(auth awxAuthConfig) (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: auth.insecureSkipVerify}
+
+	if auth.caFile != "" {
+		caCert, err := os.ReadFile(auth.caFile)
+		if err != nil {
+			return nil, err
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(caCert) {
+			return nil, fmt.Errorf("failed to parse CA bundle %q", auth.caFile)
+		}
+		tlsConfig.RootCAs = pool
+	}
+
+	if auth.certFile != "" && auth.keyFile != "" {
+		cert, err := tls.LoadX509KeyPair(auth.certFile, auth.keyFile)
+		if err != nil {
+			return nil, err
+		}
+		tlsConfig.Certificates = []tls.Certificate{cert}
+	}
+
+	return tlsConfig, nil
+}
+
+// applyAuth sets the request's auth header for the basic and bearer auth
+// types. OAuth2 needs no per-request header since it's handled transparently
+// by the client returned from newAWXHTTPClient.
+func applyAuth(req *http.Request, auth awxAuthConfig) {
+	switch auth.authType {
+	case "bearer":
+		req.Header.Set("Authorization", "Bearer "+auth.token)
+	case "oauth2":
+		// handled by the oauth2 transport
+	default:
+		req.SetBasicAuth(auth.username, auth.password)
+	}
+}
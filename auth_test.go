@@ -0,0 +1,166 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestResolveCredential(t *testing.T) {
+	t.Run("plain value passes through unchanged", func(t *testing.T) {
+		if got := resolveCredential("s3cr3t"); got != "s3cr3t" {
+			t.Errorf("resolveCredential() = %q, want %q", got, "s3cr3t")
+		}
+	})
+
+	t.Run("env prefix resolves from the environment", func(t *testing.T) {
+		t.Setenv("AWX_TEST_CREDENTIAL", "from-env")
+		if got := resolveCredential("env:AWX_TEST_CREDENTIAL"); got != "from-env" {
+			t.Errorf("resolveCredential() = %q, want %q", got, "from-env")
+		}
+	})
+
+	t.Run("env prefix with unset variable resolves empty", func(t *testing.T) {
+		if got := resolveCredential("env:AWX_TEST_CREDENTIAL_UNSET"); got != "" {
+			t.Errorf("resolveCredential() = %q, want empty", got)
+		}
+	})
+
+	t.Run("file prefix resolves and trims file contents", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "secret")
+		if err := os.WriteFile(path, []byte("from-file\n"), 0o600); err != nil {
+			t.Fatalf("writing fixture file: %v", err)
+		}
+		if got := resolveCredential("file:" + path); got != "from-file" {
+			t.Errorf("resolveCredential() = %q, want %q", got, "from-file")
+		}
+	})
+
+	t.Run("file prefix with missing file resolves empty", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "does-not-exist")
+		if got := resolveCredential("file:" + path); got != "" {
+			t.Errorf("resolveCredential() = %q, want empty", got)
+		}
+	})
+}
+
+func TestBuildTLSConfig(t *testing.T) {
+	t.Run("empty config has no CA pool or certificates", func(t *testing.T) {
+		tlsConfig, err := buildTLSConfig(awxAuthConfig{})
+		if err != nil {
+			t.Fatalf("buildTLSConfig() error = %v", err)
+		}
+		if tlsConfig.InsecureSkipVerify {
+			t.Error("InsecureSkipVerify = true, want false")
+		}
+		if tlsConfig.RootCAs != nil {
+			t.Error("RootCAs populated, want nil")
+		}
+		if len(tlsConfig.Certificates) != 0 {
+			t.Errorf("Certificates = %v, want none", tlsConfig.Certificates)
+		}
+	})
+
+	t.Run("insecure skip verify is carried through", func(t *testing.T) {
+		tlsConfig, err := buildTLSConfig(awxAuthConfig{insecureSkipVerify: true})
+		if err != nil {
+			t.Fatalf("buildTLSConfig() error = %v", err)
+		}
+		if !tlsConfig.InsecureSkipVerify {
+			t.Error("InsecureSkipVerify = false, want true")
+		}
+	})
+
+	t.Run("valid CA bundle populates RootCAs", func(t *testing.T) {
+		dir := t.TempDir()
+		certPEM, _ := generateTestCert(t)
+		caFile := filepath.Join(dir, "ca.pem")
+		if err := os.WriteFile(caFile, certPEM, 0o600); err != nil {
+			t.Fatalf("writing CA fixture: %v", err)
+		}
+
+		tlsConfig, err := buildTLSConfig(awxAuthConfig{caFile: caFile})
+		if err != nil {
+			t.Fatalf("buildTLSConfig() error = %v", err)
+		}
+		if tlsConfig.RootCAs == nil {
+			t.Fatal("RootCAs = nil, want populated pool")
+		}
+	})
+
+	t.Run("invalid CA bundle is an error", func(t *testing.T) {
+		dir := t.TempDir()
+		caFile := filepath.Join(dir, "ca.pem")
+		if err := os.WriteFile(caFile, []byte("not a certificate"), 0o600); err != nil {
+			t.Fatalf("writing CA fixture: %v", err)
+		}
+
+		if _, err := buildTLSConfig(awxAuthConfig{caFile: caFile}); err == nil {
+			t.Fatal("buildTLSConfig() error = nil, want an error for a malformed CA bundle")
+		}
+	})
+
+	t.Run("valid cert and key pair populates Certificates", func(t *testing.T) {
+		dir := t.TempDir()
+		certPEM, keyPEM := generateTestCert(t)
+		certFile := filepath.Join(dir, "client.pem")
+		keyFile := filepath.Join(dir, "client-key.pem")
+		if err := os.WriteFile(certFile, certPEM, 0o600); err != nil {
+			t.Fatalf("writing cert fixture: %v", err)
+		}
+		if err := os.WriteFile(keyFile, keyPEM, 0o600); err != nil {
+			t.Fatalf("writing key fixture: %v", err)
+		}
+
+		tlsConfig, err := buildTLSConfig(awxAuthConfig{certFile: certFile, keyFile: keyFile})
+		if err != nil {
+			t.Fatalf("buildTLSConfig() error = %v", err)
+		}
+		if len(tlsConfig.Certificates) != 1 {
+			t.Fatalf("Certificates = %v, want exactly one", tlsConfig.Certificates)
+		}
+	})
+}
+
+// generateTestCert returns a self-signed certificate and its private key,
+// both PEM-encoded, for exercising buildTLSConfig's CA bundle and mTLS
+// client certificate paths without depending on any fixture on disk.
+func generateTestCert(t *testing.T) (certPEM, keyPEM []byte) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("generating test key: %v", err)
+	}
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "awx-job-exporter-test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		IsCA:         true,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("creating test certificate: %v", err)
+	}
+	certPEM = pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	keyBytes, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatalf("marshaling test key: %v", err)
+	}
+	keyPEM = pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyBytes})
+
+	return certPEM, keyPEM
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"log"
+
+	"github.com/expr-lang/expr"
+	"github.com/expr-lang/expr/vm"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/spf13/viper"
+)
+
+var awxJobsFilteredTotal = prometheus.NewCounterVec(
+	prometheus.CounterOpts{
+		Name: "awx_jobs_filtered_total",
+		Help: "Total number of AWX jobs matched by a filter rule, labeled by rule reason and action.",
+	},
+	[]string{"reason", "action"},
+)
+
+func init() {
+	prometheus.MustRegister(awxJobsFilteredTotal)
+}
+
+// filterRule is a single whitelist/blacklist rule evaluated against an
+// AWXJob. A job matches a rule when every non-empty condition on it is
+// satisfied, ANDed together; an empty/absent condition is ignored. Rules
+// are evaluated in configured order and the first match decides the job's
+// fate, so operators control precedence through rule ordering rather than
+// a fixed allow-then-deny pass.
+type filterRule struct {
+	Reason        string   `mapstructure:"reason"`
+	Action        string   `mapstructure:"action"` // "allow" or "deny"
+	Organizations []string `mapstructure:"organizations"`
+	Labels        []string `mapstructure:"labels"`
+	JobTemplate   string   `mapstructure:"job_template"`
+	Inventory     string   `mapstructure:"inventory"`
+	Status        []string `mapstructure:"status"`
+	Expression    string   `mapstructure:"expression"`
+
+	program *vm.Program
+}
+
+// loadFilterRules reads the `filters` list from config and compiles any
+// rule expressions before any job is evaluated, so a bad expression stops
+// the exporter when the rules are loaded rather than on the first matching
+// job. It is called at the start of every Collect, so that happens on the
+// first scrape, not at process startup.
+func loadFilterRules() []filterRule {
+	var rules []filterRule
+	if err := viper.UnmarshalKey("filters", &rules); err != nil {
+		log.Fatalf("Error parsing filter rules: %v", err)
+	}
+
+	for i := range rules {
+		if rules[i].Expression == "" {
+			continue
+		}
+
+		program, err := expr.Compile(rules[i].Expression, expr.Env(AWXJob{}), expr.AsBool())
+		if err != nil {
+			log.Fatalf("Error compiling filter expression %q: %v", rules[i].Expression, err)
+		}
+		rules[i].program = program
+	}
+
+	return rules
+}
+
+// matches reports whether job satisfies every non-empty condition on r.
+func (r filterRule) matches(job AWXJob, jobLabels []string) bool {
+	if len(r.Organizations) > 0 && !contains(r.Organizations, job.SummaryFields.Organization.Name) {
+		return false
+	}
+	if len(r.Labels) > 0 && !anyContains(r.Labels, jobLabels) {
+		return false
+	}
+	if r.JobTemplate != "" && r.JobTemplate != job.SummaryFields.JobTemplate.Name {
+		return false
+	}
+	if r.Inventory != "" && r.Inventory != job.SummaryFields.Inventory.Name {
+		return false
+	}
+	if len(r.Status) > 0 && !contains(r.Status, job.Status) {
+		return false
+	}
+	if r.program != nil {
+		output, err := expr.Run(r.program, job)
+		if err != nil {
+			log.Printf("Error evaluating filter expression for rule %q: %v", r.Reason, err)
+			return false
+		}
+		if matched, ok := output.(bool); !ok || !matched {
+			return false
+		}
+	}
+
+	return true
+}
+
+// anyContains reports whether any element of want is present in have.
+func anyContains(want, have []string) bool {
+	for _, w := range want {
+		if contains(have, w) {
+			return true
+		}
+	}
+	return false
+}
+
+// evaluateFilters walks rules in order and returns whether job should be
+// kept, along with the reason and action of whichever rule decided its
+// fate. If no rule matches, the job is kept by default with an empty
+// reason/action.
+func evaluateFilters(rules []filterRule, job AWXJob, jobLabels []string) (keep bool, reason, action string) {
+	for _, rule := range rules {
+		if !rule.matches(job, jobLabels) {
+			continue
+		}
+
+		awxJobsFilteredTotal.WithLabelValues(rule.Reason, rule.Action).Inc()
+
+		return rule.Action != "deny", rule.Reason, rule.Action
+	}
+
+	return true, "", ""
+}
@@ -0,0 +1,212 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/expr-lang/expr"
+)
+
+func testJob() AWXJob {
+	job := AWXJob{
+		JobId:  101,
+		Status: "successful",
+	}
+	job.SummaryFields.Organization.Name = "Ops"
+	job.SummaryFields.JobTemplate.Name = "deploy-prod"
+	job.SummaryFields.Inventory.Name = "prod-inventory"
+	return job
+}
+
+func TestFilterRuleMatches(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   filterRule
+		job    AWXJob
+		labels []string
+		want   bool
+	}{
+		{
+			name: "empty rule matches everything",
+			rule: filterRule{},
+			job:  testJob(),
+			want: true,
+		},
+		{
+			name: "organization match",
+			rule: filterRule{Organizations: []string{"Ops", "Dev"}},
+			job:  testJob(),
+			want: true,
+		},
+		{
+			name: "organization no match",
+			rule: filterRule{Organizations: []string{"Dev"}},
+			job:  testJob(),
+			want: false,
+		},
+		{
+			name:   "any label matches",
+			rule:   filterRule{Labels: []string{"db", "qa"}},
+			job:    testJob(),
+			labels: []string{"prod", "db"},
+			want:   true,
+		},
+		{
+			name:   "no label matches",
+			rule:   filterRule{Labels: []string{"qa"}},
+			job:    testJob(),
+			labels: []string{"prod", "db"},
+			want:   false,
+		},
+		{
+			name: "job template equality",
+			rule: filterRule{JobTemplate: "deploy-prod"},
+			job:  testJob(),
+			want: true,
+		},
+		{
+			name: "job template mismatch",
+			rule: filterRule{JobTemplate: "deploy-staging"},
+			job:  testJob(),
+			want: false,
+		},
+		{
+			name: "inventory equality",
+			rule: filterRule{Inventory: "prod-inventory"},
+			job:  testJob(),
+			want: true,
+		},
+		{
+			name: "inventory mismatch",
+			rule: filterRule{Inventory: "staging-inventory"},
+			job:  testJob(),
+			want: false,
+		},
+		{
+			name: "status membership",
+			rule: filterRule{Status: []string{"failed", "successful"}},
+			job:  testJob(),
+			want: true,
+		},
+		{
+			name: "status no membership",
+			rule: filterRule{Status: []string{"failed"}},
+			job:  testJob(),
+			want: false,
+		},
+		{
+			name: "combined conditions all satisfied",
+			rule: filterRule{Organizations: []string{"Ops"}, Status: []string{"successful"}},
+			job:  testJob(),
+			want: true,
+		},
+		{
+			name: "combined conditions one unsatisfied",
+			rule: filterRule{Organizations: []string{"Ops"}, Status: []string{"failed"}},
+			job:  testJob(),
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.rule.matches(tt.job, tt.labels); got != tt.want {
+				t.Errorf("matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFilterRuleMatchesExpression(t *testing.T) {
+	compile := func(t *testing.T, src string) filterRule {
+		t.Helper()
+		program, err := expr.Compile(src, expr.Env(AWXJob{}), expr.AsBool())
+		if err != nil {
+			t.Fatalf("compiling expression %q: %v", src, err)
+		}
+		return filterRule{Expression: src, program: program}
+	}
+
+	t.Run("expression matches", func(t *testing.T) {
+		rule := compile(t, `Status == "successful"`)
+		if !rule.matches(testJob(), nil) {
+			t.Error("matches() = false, want true")
+		}
+	})
+
+	t.Run("expression does not match", func(t *testing.T) {
+		rule := compile(t, `Status == "failed"`)
+		if rule.matches(testJob(), nil) {
+			t.Error("matches() = true, want false")
+		}
+	})
+
+	t.Run("expression combined with other conditions", func(t *testing.T) {
+		rule := compile(t, `Status == "successful"`)
+		rule.Organizations = []string{"Dev"}
+		if rule.matches(testJob(), nil) {
+			t.Error("matches() = true, want false because organization condition fails first")
+		}
+	})
+}
+
+func TestEvaluateFilters(t *testing.T) {
+	t.Run("no rules keeps the job by default", func(t *testing.T) {
+		keep, reason, action := evaluateFilters(nil, testJob(), nil)
+		if !keep {
+			t.Error("keep = false, want true")
+		}
+		if reason != "" || action != "" {
+			t.Errorf("reason/action = %q/%q, want empty", reason, action)
+		}
+	})
+
+	t.Run("no rule matches keeps the job by default", func(t *testing.T) {
+		rules := []filterRule{{Organizations: []string{"Dev"}, Reason: "dev-only", Action: "allow"}}
+		keep, reason, action := evaluateFilters(rules, testJob(), nil)
+		if !keep {
+			t.Error("keep = false, want true")
+		}
+		if reason != "" || action != "" {
+			t.Errorf("reason/action = %q/%q, want empty", reason, action)
+		}
+	})
+
+	t.Run("first matching allow rule wins", func(t *testing.T) {
+		rules := []filterRule{
+			{Organizations: []string{"Ops"}, Reason: "ops-allow", Action: "allow"},
+			{Status: []string{"successful"}, Reason: "status-allow", Action: "allow"},
+		}
+		keep, reason, action := evaluateFilters(rules, testJob(), nil)
+		if !keep {
+			t.Error("keep = false, want true")
+		}
+		if reason != "ops-allow" || action != "allow" {
+			t.Errorf("reason/action = %q/%q, want %q/%q", reason, action, "ops-allow", "allow")
+		}
+	})
+
+	t.Run("matching deny rule drops the job", func(t *testing.T) {
+		rules := []filterRule{{Organizations: []string{"Ops"}, Reason: "ops-deny", Action: "deny"}}
+		keep, reason, action := evaluateFilters(rules, testJob(), nil)
+		if keep {
+			t.Error("keep = true, want false")
+		}
+		if reason != "ops-deny" || action != "deny" {
+			t.Errorf("reason/action = %q/%q, want %q/%q", reason, action, "ops-deny", "deny")
+		}
+	})
+
+	t.Run("non-matching rule is skipped in favor of a later match", func(t *testing.T) {
+		rules := []filterRule{
+			{Organizations: []string{"Dev"}, Reason: "dev-deny", Action: "deny"},
+			{Organizations: []string{"Ops"}, Reason: "ops-allow", Action: "allow"},
+		}
+		keep, reason, action := evaluateFilters(rules, testJob(), nil)
+		if !keep {
+			t.Error("keep = false, want true")
+		}
+		if reason != "ops-allow" || action != "allow" {
+			t.Errorf("reason/action = %q/%q, want %q/%q", reason, action, "ops-allow", "allow")
+		}
+	})
+}
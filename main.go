@@ -2,9 +2,12 @@ package main
 
 import (
 	"encoding/json"
+	"fmt"
 	"log"
 	"net/http"
-	"strings"
+	"net/url"
+	"strconv"
+	"sync"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
@@ -12,39 +15,59 @@ import (
 	"github.com/spf13/viper"
 )
 
-var (
-	awxJobsTotal = prometheus.NewGaugeVec(
-		prometheus.GaugeOpts{
-			Name: "awx_jobs_total",
-			Help: "Total number of AWX jobs per organization, status, and job labels.",
-		},
-		[]string{"organization", "status", "job_labels"},
-	)
-
-	whitelistOrganizations []string
-	whitelistLabels        []string
-	whitelistEnabled       bool
-)
-
 func init() {
-	prometheus.MustRegister(awxJobsTotal)
 	loadConfig()
 }
 
 type AWXJob struct {
-	Status string `json:"status"`
-	Labels []struct {
-		Name string `json:"name"`
-	} `json:"summary_fields.labels"`
-	Organization struct {
-		Name string `json:"name"`
-	} `json:"summary_fields.organization"`
-	JobId   int     `json:"id"`
-	Elapsed float32 `json:"elapsed"`
+	JobId    int     `json:"id"`
+	Status   string  `json:"status"`
+	Elapsed  float32 `json:"elapsed"`
+	Finished string  `json:"finished"`
+
+	SummaryFields AWXJobSummaryFields `json:"summary_fields"`
+}
+
+// AWXJobSummaryFields mirrors the subset of a job's `summary_fields` object
+// this exporter reads. encoding/json has no notion of a dotted tag path like
+// "summary_fields.organization.name" - it only resolves nested JSON objects
+// through nested Go structs - so this must mirror AWX's actual JSON shape
+// field for field rather than flattening it onto AWXJob.
+type AWXJobSummaryFields struct {
+	Organization AWXNamedResource `json:"organization"`
+	JobTemplate  AWXNamedResource `json:"job_template"`
+	Inventory    AWXNamedResource `json:"inventory"`
+	Labels       AWXJobLabels     `json:"labels"`
+}
+
+// AWXNamedResource is the `{"id": ..., "name": ...}` shape AWX uses for most
+// summary_fields sub-objects.
+type AWXNamedResource struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// AWXJobLabels mirrors AWX's `summary_fields.labels`, which is itself a
+// paginated-looking object (`count` + `results`) rather than a bare array.
+type AWXJobLabels struct {
+	Count   int                `json:"count"`
+	Results []AWXNamedResource `json:"results"`
+}
+
+// labelNames returns the job's label names in AWX's reported order.
+func (j AWXJob) labelNames() []string {
+	names := make([]string, 0, len(j.SummaryFields.Labels.Results))
+	for _, label := range j.SummaryFields.Labels.Results {
+		names = append(names, label.Name)
+	}
+	return names
 }
 
 type AWXResponse struct {
-	Results []AWXJob `json:"results"`
+	Count    int      `json:"count"`
+	Next     string   `json:"next"`
+	Previous string   `json:"previous"`
+	Results  []AWXJob `json:"results"`
 }
 
 func loadConfig() {
@@ -55,65 +78,189 @@ func loadConfig() {
 
 	// Standardwerte setzen
 	viper.SetDefault("awx.api_url", "http://your-awx-url/api/v2/jobs/")
-	viper.SetDefault("awx.username", "your-username")
-	viper.SetDefault("awx.password", "your-password")
+	viper.SetDefault("awx.page_size", 200)
+	viper.SetDefault("awx.order_by", "-id")
+	viper.SetDefault("awx.max_pages", 100)
+	viper.SetDefault("awx.page_timeout", 10*time.Second)
+	viper.SetDefault("awx.auth.type", "basic")
+	viper.SetDefault("awx.auth.username", "your-username")
+	viper.SetDefault("awx.auth.password", "your-password")
+	viper.SetDefault("awx.tls.insecure_skip_verify", false)
+	viper.SetDefault("awx.histogram.native_bucket_factor", 0.0)
+	viper.SetDefault("debug.strict_schema", false)
 
 	err := viper.ReadInConfig() // Versuche, die Konfigurationsdatei zu lesen
 	if err != nil {
-		log.Fatalf("Error loading config file: %v", err)
+		if _, notFound := err.(viper.ConfigFileNotFoundError); !notFound {
+			log.Fatalf("Error loading config file: %v", err)
+		}
+		log.Println("No config file found, using defaults and environment variables")
 	}
 
-	whitelistOrganizations = viper.GetStringSlice("whitelist.organizations")
-	whitelistLabels = viper.GetStringSlice("whitelist.labels")
-	whitelistEnabled = viper.GetBool("whitelist.enabled")
-
 	log.Println("Loaded configuration:")
 	log.Printf("AWX API URL: %s", viper.GetString("awx.api_url"))
-	log.Printf("Whitelist enabled: %v", whitelistEnabled)
-	log.Printf("Whitelisted organizations: %v", whitelistOrganizations)
-	log.Printf("Whitelisted labels: %v", whitelistLabels)
 }
 
-func fetchAWXJobData(apiURL, username, password string) (*AWXResponse, error) {
-	client := &http.Client{
-		Timeout: 10 * time.Second,
+// awxFetchConfig bundles the knobs needed to walk AWX's paginated job list.
+type awxFetchConfig struct {
+	apiURL      string
+	auth        awxAuthConfig
+	pageSize    int
+	orderBy     string
+	maxPages    int
+	pageTimeout time.Duration
+}
+
+// fetchAWXJobData walks AWX's `next` pagination cursor starting at cfg.apiURL,
+// aggregating every page's results into a single AWXResponse. It stops once
+// AWX reports no further `next` link or cfg.maxPages pages have been fetched,
+// whichever comes first.
+func fetchAWXJobData(cfg awxFetchConfig) (*AWXResponse, error) {
+	client, err := newAWXHTTPClient(cfg.auth, cfg.pageTimeout)
+	if err != nil {
+		return nil, err
 	}
 
-	req, err := http.NewRequest("GET", apiURL, nil)
+	nextURL, err := withPagingParams(cfg.apiURL, cfg.pageSize, cfg.orderBy)
 	if err != nil {
 		return nil, err
 	}
 
-	req.SetBasicAuth(username, password)
+	aggregated := &AWXResponse{}
+
+	for page := 0; nextURL != "" && page < cfg.maxPages; page++ {
+		req, err := http.NewRequest("GET", nextURL, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		applyAuth(req, cfg.auth)
+
+		resp, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		var result AWXResponse
+		decoder := json.NewDecoder(resp.Body)
+		if viper.GetBool("debug.strict_schema") {
+			// Surfaces AWX payload shape changes as hard decode errors instead
+			// of silently-empty fields. Off by default: a real AWX job carries
+			// many more top-level fields than the subset modeled on AWXJob, so
+			// this is only useful against a pared-down fixture (see
+			// testdata/awx_job_page.json) that matches AWXJob field for field.
+			decoder.DisallowUnknownFields()
+		}
+		decodeErr := decoder.Decode(&result)
+		resp.Body.Close()
+		if decodeErr != nil {
+			return nil, decodeErr
+		}
+
+		aggregated.Results = append(aggregated.Results, result.Results...)
+
+		nextURL, err = resolveNextURL(cfg.apiURL, result.Next)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return aggregated, nil
+}
 
-	resp, err := client.Do(req)
+// withPagingParams applies the configured page_size and order_by query
+// params to apiURL, leaving any other params already present untouched. A
+// page_size or order_by already in apiURL is replaced when pageSize is above
+// zero or orderBy is non-empty, respectively.
+func withPagingParams(apiURL string, pageSize int, orderBy string) (string, error) {
+	parsed, err := url.Parse(apiURL)
 	if err != nil {
-		return nil, err
+		return "", err
 	}
-	defer resp.Body.Close()
 
-	var result AWXResponse
-	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil {
-		return nil, err
+	query := parsed.Query()
+	if pageSize > 0 {
+		query.Set("page_size", fmt.Sprintf("%d", pageSize))
+	}
+	if orderBy != "" {
+		query.Set("order_by", orderBy)
 	}
+	parsed.RawQuery = query.Encode()
 
-	return &result, nil
+	return parsed.String(), nil
 }
 
-func isWhitelisted(organization string, jobLabels []string) bool {
-	// Überprüfe, ob die Organisation in der Whitelist ist
-	if !contains(whitelistOrganizations, organization) {
-		return false
+// resolveNextURL turns AWX's (possibly relative) `next` field into an
+// absolute URL resolved against the original request URL, or "" once AWX
+// reports no further pages.
+func resolveNextURL(apiURL, next string) (string, error) {
+	if next == "" {
+		return "", nil
 	}
 
-	// Überprüfe, ob mindestens ein Label in der Whitelist ist
-	for _, label := range jobLabels {
-		if contains(whitelistLabels, label) {
-			return true
+	base, err := url.Parse(apiURL)
+	if err != nil {
+		return "", err
+	}
+
+	rel, err := url.Parse(next)
+	if err != nil {
+		return "", err
+	}
+
+	return base.ResolveReference(rel).String(), nil
+}
+
+// histogramBuckets returns the configured awx_job_duration_seconds bucket
+// boundaries, falling back to prometheus.DefBuckets when unset. Viper has no
+// GetFloat64Slice, so bucket values are read as strings and parsed.
+func histogramBuckets() []float64 {
+	raw := viper.GetStringSlice("awx.histogram.buckets")
+	if len(raw) == 0 {
+		return prometheus.DefBuckets
+	}
+
+	buckets := make([]float64, 0, len(raw))
+	for _, v := range raw {
+		b, err := strconv.ParseFloat(v, 64)
+		if err != nil {
+			log.Printf("Error parsing histogram bucket %q: %v", v, err)
+			continue
 		}
+		buckets = append(buckets, b)
 	}
 
-	return false
+	if len(buckets) == 0 {
+		return prometheus.DefBuckets
+	}
+
+	return buckets
+}
+
+// durationAgg accumulates awx_job_duration_seconds observations for a single
+// organization/job_template/status group within one scrape.
+type durationAgg struct {
+	count        uint64
+	sum          float64
+	bucketCounts map[float64]uint64
+}
+
+func newDurationAgg(buckets []float64) *durationAgg {
+	agg := &durationAgg{bucketCounts: make(map[float64]uint64, len(buckets))}
+	for _, b := range buckets {
+		agg.bucketCounts[b] = 0
+	}
+	return agg
+}
+
+func (a *durationAgg) observe(elapsed float64, buckets []float64) {
+	a.count++
+	a.sum += elapsed
+	for _, b := range buckets {
+		if elapsed <= b {
+			a.bucketCounts[b]++
+		}
+	}
 }
 
 func contains(slice []string, item string) bool {
@@ -125,67 +272,217 @@ func contains(slice []string, item string) bool {
 	return false
 }
 
-func recordAWXMetrics() {
-	go func() {
-		for {
-			apiURL := viper.GetString("awx.api_url")
-			username := viper.GetString("awx.username")
-			password := viper.GetString("awx.password")
+// AWXCollector is a prometheus.Collector that fetches AWX job data on
+// demand every time Collect is invoked, instead of relying on a background
+// polling loop writing into module-level gauges. This means a scrape never
+// reports a stale count for a job that has since vanished from AWX, and the
+// scrape latency and outcome are themselves observable via awx_up and
+// awx_scrape_duration_seconds.
+type AWXCollector struct {
+	jobsDesc           *prometheus.Desc
+	upDesc             *prometheus.Desc
+	scrapeDurationDesc *prometheus.Desc
+	scrapeErrorsDesc   *prometheus.Desc
+	jobDurationDesc    *prometheus.Desc
+	lastFinishedDesc   *prometheus.Desc
+
+	// nativeDurationHist additionally exports awx_job_duration_seconds_native
+	// as a native (sparse) histogram when awx.histogram.native_bucket_factor
+	// is configured; it's a regular registered metric rather than a const one
+	// since native histograms aren't supported through MustNewConstHistogram.
+	nativeDurationHist *prometheus.HistogramVec
+
+	mu           sync.Mutex
+	scrapeErrors float64
+}
 
-			awxResponse, err := fetchAWXJobData(apiURL, username, password)
-			if err != nil {
-				log.Printf("Error fetching AWX job data: %v", err)
-				continue
-			}
+// NewAWXCollector builds an AWXCollector with its metric descriptors set up.
+func NewAWXCollector() *AWXCollector {
+	c := &AWXCollector{
+		jobsDesc: prometheus.NewDesc(
+			"awx_jobs_total",
+			"Total number of AWX jobs per organization, status, and job label. One series per label value, not a comma-joined list.",
+			[]string{"organization", "status", "job_labels"}, nil,
+		),
+		upDesc: prometheus.NewDesc(
+			"awx_up",
+			"Whether the last scrape of the AWX API succeeded (1) or failed (0).",
+			nil, nil,
+		),
+		scrapeDurationDesc: prometheus.NewDesc(
+			"awx_scrape_duration_seconds",
+			"Time the last scrape of the AWX API took, in seconds.",
+			nil, nil,
+		),
+		scrapeErrorsDesc: prometheus.NewDesc(
+			"awx_scrape_errors_total",
+			"Total number of errors encountered while scraping the AWX API.",
+			nil, nil,
+		),
+		jobDurationDesc: prometheus.NewDesc(
+			"awx_job_duration_seconds",
+			"Histogram of AWX job elapsed run time in seconds, by organization, job_template, and status.",
+			[]string{"organization", "job_template", "status"}, nil,
+		),
+		lastFinishedDesc: prometheus.NewDesc(
+			"awx_job_last_finished_timestamp_seconds",
+			"Unix timestamp of the most recently finished job seen in the last scrape, by organization, job_template, and status.",
+			[]string{"organization", "job_template", "status"}, nil,
+		),
+	}
 
-			jobCountByOrgStatusAndLabel := make(map[string]map[string]map[string]int)
+	if factor := viper.GetFloat64("awx.histogram.native_bucket_factor"); factor > 0 {
+		// client_golang only turns on native/sparse buckets above a factor of
+		// 1; anything in (0, 1] would silently register a classic histogram
+		// with DefBuckets instead of the native variant this promises.
+		if factor <= 1 {
+			log.Printf("awx.histogram.native_bucket_factor=%v must be > 1 to enable native histograms; ignoring", factor)
+		} else {
+			c.nativeDurationHist = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+				Name:                        "awx_job_duration_seconds_native",
+				Help:                        "Native (sparse) histogram variant of awx_job_duration_seconds.",
+				NativeHistogramBucketFactor: factor,
+			}, []string{"organization", "job_template", "status"})
+			prometheus.MustRegister(c.nativeDurationHist)
+		}
+	}
 
-			for _, job := range awxResponse.Results {
-				orgName := job.Organization.Name
-				jobID := job.JobId
-				// Kombiniere alle Job-Labels zu einem String
-				var jobLabels []string
-				for _, label := range job.Labels {
-					jobLabels = append(jobLabels, label.Name)
-				}
-				combinedLabels := strings.Join(jobLabels, ",")
-
-				// Filtere Jobs nach Whitelist
-				if whitelistEnabled {
-					if !isWhitelisted(orgName, jobLabels) {
-						log.Printf("Ignoring JobID as its not on the whitelist %v", jobID)
-						continue
-					}
-				}
+	return c
+}
 
-				jobStatus := job.Status
+// Describe implements prometheus.Collector.
+func (c *AWXCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.jobsDesc
+	ch <- c.upDesc
+	ch <- c.scrapeDurationDesc
+	ch <- c.scrapeErrorsDesc
+	ch <- c.jobDurationDesc
+	ch <- c.lastFinishedDesc
+}
 
-				if _, exists := jobCountByOrgStatusAndLabel[orgName]; !exists {
-					jobCountByOrgStatusAndLabel[orgName] = make(map[string]map[string]int)
-				}
-				if _, exists := jobCountByOrgStatusAndLabel[orgName][jobStatus]; !exists {
-					jobCountByOrgStatusAndLabel[orgName][jobStatus] = make(map[string]int)
-				}
+// Collect implements prometheus.Collector by fetching fresh AWX job data and
+// translating it directly into const metrics for this scrape.
+func (c *AWXCollector) Collect(ch chan<- prometheus.Metric) {
+	start := time.Now()
 
-				jobCountByOrgStatusAndLabel[orgName][jobStatus][combinedLabels]++
-			}
+	awxResponse, err := fetchAWXJobData(awxFetchConfig{
+		apiURL:      viper.GetString("awx.api_url"),
+		auth:        loadAWXAuthConfig(),
+		pageSize:    viper.GetInt("awx.page_size"),
+		orderBy:     viper.GetString("awx.order_by"),
+		maxPages:    viper.GetInt("awx.max_pages"),
+		pageTimeout: viper.GetDuration("awx.page_timeout"),
+	})
+
+	ch <- prometheus.MustNewConstMetric(c.scrapeDurationDesc, prometheus.GaugeValue, time.Since(start).Seconds())
+
+	if err != nil {
+		log.Printf("Error fetching AWX job data: %v", err)
+		ch <- prometheus.MustNewConstMetric(c.upDesc, prometheus.GaugeValue, 0)
+		ch <- prometheus.MustNewConstMetric(c.scrapeErrorsDesc, prometheus.CounterValue, c.incScrapeErrors())
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.upDesc, prometheus.GaugeValue, 1)
+	ch <- prometheus.MustNewConstMetric(c.scrapeErrorsDesc, prometheus.CounterValue, c.readScrapeErrors())
+
+	filterRules := loadFilterRules()
+	buckets := histogramBuckets()
+
+	jobCountByOrgStatusAndLabel := make(map[string]map[string]map[string]int)
+	durationByGroup := make(map[[3]string]*durationAgg)
+	lastFinishedByGroup := make(map[[3]string]float64)
+
+	for _, job := range awxResponse.Results {
+		orgName := job.SummaryFields.Organization.Name
+		jobID := job.JobId
+		jobLabels := job.labelNames()
 
-			// Aktualisiere die Metriken mit den gesammelten Daten
-			for org, statusMap := range jobCountByOrgStatusAndLabel {
-				for status, labelMap := range statusMap {
-					for labels, count := range labelMap {
-						awxJobsTotal.WithLabelValues(org, status, labels).Set(float64(count))
-					}
+		if keep, reason, action := evaluateFilters(filterRules, job, jobLabels); !keep {
+			log.Printf("Ignoring JobID %v due to filter rule %q (%s)", jobID, reason, action)
+			continue
+		}
+
+		jobStatus := job.Status
+
+		if _, exists := jobCountByOrgStatusAndLabel[orgName]; !exists {
+			jobCountByOrgStatusAndLabel[orgName] = make(map[string]map[string]int)
+		}
+		if _, exists := jobCountByOrgStatusAndLabel[orgName][jobStatus]; !exists {
+			jobCountByOrgStatusAndLabel[orgName][jobStatus] = make(map[string]int)
+		}
+
+		// One series per label value instead of a comma-joined string, so a
+		// job with labels [prod, db] counts toward both job_labels="prod" and
+		// job_labels="db" rather than the unwieldy job_labels="prod,db".
+		labelsForCounting := jobLabels
+		if len(labelsForCounting) == 0 {
+			labelsForCounting = []string{""}
+		}
+		for _, label := range labelsForCounting {
+			jobCountByOrgStatusAndLabel[orgName][jobStatus][label]++
+		}
+
+		groupKey := [3]string{orgName, job.SummaryFields.JobTemplate.Name, jobStatus}
+
+		agg, exists := durationByGroup[groupKey]
+		if !exists {
+			agg = newDurationAgg(buckets)
+			durationByGroup[groupKey] = agg
+		}
+		elapsed := float64(job.Elapsed)
+		agg.observe(elapsed, buckets)
+
+		if c.nativeDurationHist != nil {
+			c.nativeDurationHist.WithLabelValues(groupKey[0], groupKey[1], groupKey[2]).Observe(elapsed)
+		}
+
+		if job.Finished != "" {
+			if finishedAt, err := time.Parse(time.RFC3339Nano, job.Finished); err == nil {
+				if ts := float64(finishedAt.Unix()); ts > lastFinishedByGroup[groupKey] {
+					lastFinishedByGroup[groupKey] = ts
 				}
+			} else {
+				log.Printf("Error parsing finished timestamp for JobID %v: %v", jobID, err)
 			}
+		}
+	}
 
-			time.Sleep(10 * time.Second)
+	for org, statusMap := range jobCountByOrgStatusAndLabel {
+		for status, labelMap := range statusMap {
+			for labels, count := range labelMap {
+				ch <- prometheus.MustNewConstMetric(c.jobsDesc, prometheus.GaugeValue, float64(count), org, status, labels)
+			}
 		}
-	}()
+	}
+
+	for key, agg := range durationByGroup {
+		ch <- prometheus.MustNewConstHistogram(c.jobDurationDesc, agg.count, agg.sum, agg.bucketCounts, key[0], key[1], key[2])
+	}
+
+	for key, ts := range lastFinishedByGroup {
+		ch <- prometheus.MustNewConstMetric(c.lastFinishedDesc, prometheus.GaugeValue, ts, key[0], key[1], key[2])
+	}
+}
+
+// incScrapeErrors bumps the cumulative scrape error count and returns the
+// new total, keeping awx_scrape_errors_total monotonically increasing across
+// Collect calls as a counter must be.
+func (c *AWXCollector) incScrapeErrors() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.scrapeErrors++
+	return c.scrapeErrors
+}
+
+func (c *AWXCollector) readScrapeErrors() float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.scrapeErrors
 }
 
 func main() {
-	recordAWXMetrics()
+	prometheus.MustRegister(NewAWXCollector())
 
 	http.Handle("/metrics", promhttp.Handler())
 	log.Println("AWX Job Exporter started on :8080")
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"testing"
+)
+
+// TestAWXJobDecodesSummaryFields decodes a recorded AWX /api/v2/jobs/ page
+// and checks that organization, job_template, inventory, and labels all come
+// through populated. This guards against regressions in how AWXJob mirrors
+// AWX's nested summary_fields shape.
+func TestAWXJobDecodesSummaryFields(t *testing.T) {
+	data, err := os.ReadFile("testdata/awx_job_page.json")
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+
+	var resp AWXResponse
+	if err := json.Unmarshal(data, &resp); err != nil {
+		t.Fatalf("decoding fixture: %v", err)
+	}
+
+	if len(resp.Results) != 2 {
+		t.Fatalf("expected 2 jobs, got %d", len(resp.Results))
+	}
+
+	first := resp.Results[0]
+	if first.SummaryFields.Organization.Name != "Ops" {
+		t.Errorf("organization = %q, want %q", first.SummaryFields.Organization.Name, "Ops")
+	}
+	if first.SummaryFields.JobTemplate.Name != "deploy-prod" {
+		t.Errorf("job_template = %q, want %q", first.SummaryFields.JobTemplate.Name, "deploy-prod")
+	}
+	if first.SummaryFields.Inventory.Name != "prod-inventory" {
+		t.Errorf("inventory = %q, want %q", first.SummaryFields.Inventory.Name, "prod-inventory")
+	}
+
+	wantLabels := []string{"prod", "db"}
+	gotLabels := first.labelNames()
+	if len(gotLabels) != len(wantLabels) {
+		t.Fatalf("labels = %v, want %v", gotLabels, wantLabels)
+	}
+	for i, want := range wantLabels {
+		if gotLabels[i] != want {
+			t.Errorf("labels[%d] = %q, want %q", i, gotLabels[i], want)
+		}
+	}
+
+	second := resp.Results[1]
+	if got := second.labelNames(); len(got) != 0 {
+		t.Errorf("expected no labels for second job, got %v", got)
+	}
+	if second.SummaryFields.Organization.Name != "Dev" {
+		t.Errorf("organization = %q, want %q", second.SummaryFields.Organization.Name, "Dev")
+	}
+}
+
+// TestAWXJobPageFixtureDecodesInStrictSchemaMode exercises the same
+// DisallowUnknownFields() path that debug.strict_schema enables in
+// fetchAWXJobData, against this package's own "known-good" fixture. It only
+// proves AWXResponse/AWXJob/AWXJobSummaryFields model the fixture's shape
+// field for field, not that they model a full real AWX payload.
+func TestAWXJobPageFixtureDecodesInStrictSchemaMode(t *testing.T) {
+	data, err := os.ReadFile("testdata/awx_job_page.json")
+	if err != nil {
+		t.Fatalf("reading fixture: %v", err)
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+
+	var resp AWXResponse
+	if err := decoder.Decode(&resp); err != nil {
+		t.Fatalf("strict decode of testdata/awx_job_page.json: %v", err)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import "testing"
+
+func TestWithPagingParams(t *testing.T) {
+	tests := []struct {
+		name     string
+		apiURL   string
+		pageSize int
+		orderBy  string
+		want     string
+	}{
+		{
+			name:     "adds page size and order by",
+			apiURL:   "http://awx.example.com/api/v2/jobs/",
+			pageSize: 50,
+			orderBy:  "-id",
+			want:     "http://awx.example.com/api/v2/jobs/?order_by=-id&page_size=50",
+		},
+		{
+			name:     "zero page size is omitted",
+			apiURL:   "http://awx.example.com/api/v2/jobs/",
+			pageSize: 0,
+			orderBy:  "-id",
+			want:     "http://awx.example.com/api/v2/jobs/?order_by=-id",
+		},
+		{
+			name:     "empty order by is omitted",
+			apiURL:   "http://awx.example.com/api/v2/jobs/",
+			pageSize: 50,
+			orderBy:  "",
+			want:     "http://awx.example.com/api/v2/jobs/?page_size=50",
+		},
+		{
+			name:     "preserves existing query params",
+			apiURL:   "http://awx.example.com/api/v2/jobs/?status=successful",
+			pageSize: 50,
+			orderBy:  "-id",
+			want:     "http://awx.example.com/api/v2/jobs/?order_by=-id&page_size=50&status=successful",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := withPagingParams(tt.apiURL, tt.pageSize, tt.orderBy)
+			if err != nil {
+				t.Fatalf("withPagingParams() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("withPagingParams() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveNextURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		apiURL string
+		next   string
+		want   string
+	}{
+		{
+			name:   "empty next means no further pages",
+			apiURL: "http://awx.example.com/api/v2/jobs/?page=1",
+			next:   "",
+			want:   "",
+		},
+		{
+			name:   "relative next resolves against the request host",
+			apiURL: "http://awx.example.com/api/v2/jobs/?page=1",
+			next:   "/api/v2/jobs/?page=2",
+			want:   "http://awx.example.com/api/v2/jobs/?page=2",
+		},
+		{
+			name:   "absolute next is returned as-is",
+			apiURL: "http://awx.example.com/api/v2/jobs/?page=1",
+			next:   "https://other-awx.example.com/api/v2/jobs/?page=2",
+			want:   "https://other-awx.example.com/api/v2/jobs/?page=2",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := resolveNextURL(tt.apiURL, tt.next)
+			if err != nil {
+				t.Fatalf("resolveNextURL() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("resolveNextURL() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}